@@ -0,0 +1,203 @@
+package ir
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// wireMagic and wireVersion identify the binary wire format Encode and
+// Decode produce and consume: a 4-byte magic, a version byte, then a
+// varint-encoded (mods, keycode, rune, action, repeat, delay_ms,
+// is_delay) tuple per Event with no further framing (varints are
+// self-delimiting).
+var wireMagic = [4]byte{'K', 'B', 'I', 'R'}
+
+// wireVersion 2 added rune and is_delay to the tuple; a v1 sender's
+// Event.IsDelay was indistinguishable from a tap of key code 0 (XK_A) on
+// decode, silently turning a Parse "<wait:...>" delay into a keystroke.
+const wireVersion = 2
+
+// Encode serializes events into the package's compact binary wire
+// format, suitable for sending a captured key sequence to another
+// platform over a byte stream (see Stream for the incremental version).
+func Encode(events []Event) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.Write(wireMagic[:])
+	buf.WriteByte(wireVersion)
+	for _, e := range events {
+		if err := writeEventTuple(buf, e); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode parses the wire format Encode produces back into Events.
+func Decode(b []byte) ([]Event, error) {
+	r := bufio.NewReader(bytes.NewReader(b))
+	if err := readHeader(r); err != nil {
+		return nil, err
+	}
+	var events []Event
+	for {
+		e, err := readEventTuple(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// EncodeJSON renders events as JSON, for transports where a human or a
+// log line needs to read the payload rather than a binary stream.
+func EncodeJSON(events []Event) ([]byte, error) {
+	return json.Marshal(events)
+}
+
+// DecodeJSON parses the output of EncodeJSON back into Events.
+func DecodeJSON(b []byte) ([]Event, error) {
+	var events []Event
+	if err := json.Unmarshal(b, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func readHeader(r io.Reader) error {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return fmt.Errorf("ir: reading wire header: %v", err)
+	}
+	if magic != wireMagic {
+		return fmt.Errorf("ir: bad wire magic %q, want %q", magic, wireMagic)
+	}
+	var version [1]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return fmt.Errorf("ir: reading wire version: %v", err)
+	}
+	if version[0] != wireVersion {
+		return fmt.Errorf("ir: unsupported wire version %d", version[0])
+	}
+	return nil
+}
+
+func writeEventTuple(w io.Writer, e Event) error {
+	var buf [binary.MaxVarintLen64]byte
+	write := func(v int64) error {
+		n := binary.PutVarint(buf[:], v)
+		_, err := w.Write(buf[:n])
+		return err
+	}
+	if err := write(int64(e.Mods)); err != nil {
+		return err
+	}
+	if err := write(int64(e.Key)); err != nil {
+		return err
+	}
+	if err := write(int64(e.Rune)); err != nil {
+		return err
+	}
+	if err := write(int64(e.Action)); err != nil {
+		return err
+	}
+	if err := write(int64(e.Repeat)); err != nil {
+		return err
+	}
+	if err := write(e.Delay.Milliseconds()); err != nil {
+		return err
+	}
+	var isDelay int64
+	if e.IsDelay {
+		isDelay = 1
+	}
+	return write(isDelay)
+}
+
+func readEventTuple(r io.ByteReader) (Event, error) {
+	mods, err := binary.ReadVarint(r)
+	if err != nil {
+		return Event{}, err
+	}
+	key, err := binary.ReadVarint(r)
+	if err != nil {
+		return Event{}, err
+	}
+	rn, err := binary.ReadVarint(r)
+	if err != nil {
+		return Event{}, err
+	}
+	action, err := binary.ReadVarint(r)
+	if err != nil {
+		return Event{}, err
+	}
+	repeat, err := binary.ReadVarint(r)
+	if err != nil {
+		return Event{}, err
+	}
+	delay, err := binary.ReadVarint(r)
+	if err != nil {
+		return Event{}, err
+	}
+	isDelay, err := binary.ReadVarint(r)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		Mods:    Modifier(mods),
+		Key:     int(key),
+		Rune:    rune(rn),
+		Action:  Action(action),
+		Repeat:  int(repeat),
+		Delay:   time.Duration(delay) * time.Millisecond,
+		IsDelay: isDelay != 0,
+	}, nil
+}
+
+// Stream reads and writes the wire format incrementally, one Event at a
+// time, so a sender can push events as they're captured instead of
+// buffering a whole sequence before transmitting.
+//
+// ReadEvent wraps the first io.Reader it's given in a buffered reader
+// and reuses it on every later call, so every call to ReadEvent on one
+// Stream must be given the same underlying stream (e.g. the same
+// net.Conn or websocket connection).
+type Stream struct {
+	in         *bufio.Reader
+	headerSent bool
+}
+
+// ReadEvent reads one Event from r, reading and validating the wire
+// header first if this is the first call.
+func (s *Stream) ReadEvent(r io.Reader) (Event, error) {
+	if s.in == nil {
+		s.in = bufio.NewReader(r)
+		if err := readHeader(s.in); err != nil {
+			return Event{}, err
+		}
+	}
+	return readEventTuple(s.in)
+}
+
+// WriteEvent writes one Event to w, writing the wire header first if
+// this is the first call.
+func (s *Stream) WriteEvent(w io.Writer, e Event) error {
+	if !s.headerSent {
+		if _, err := w.Write(wireMagic[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{wireVersion}); err != nil {
+			return err
+		}
+		s.headerSent = true
+	}
+	return writeEventTuple(w, e)
+}
@@ -0,0 +1,87 @@
+package ir
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	events := []Event{
+		{Mods: ModShift, Key: XK_H, Rune: 'H', Action: ActionTap},
+		{Key: XK_Control, Action: ActionPress},
+		{Key: XK_TAB, Action: ActionTap, Repeat: 3},
+		{Delay: 250 * time.Millisecond, IsDelay: true},
+	}
+
+	b, err := Encode(events)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	got, err := Decode(b)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("e %#v, r %#v", events, got)
+	}
+}
+
+// TestEncodeDecodePreservesDelay guards against IsDelay being collapsed
+// into a tap of key code 0 (XK_A) across the wire, which would silently
+// turn a Parse "<wait:...>" pause into a keystroke for a receiver that
+// replays decoded Events.
+func TestEncodeDecodePreservesDelay(t *testing.T) {
+	events, err := Parse("<wait:500ms>")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	b, err := Encode(events)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	got, err := Decode(b)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("e %#v, r %#v", events, got)
+	}
+	if !got[0].IsDelay {
+		t.Errorf("decoded event lost IsDelay: %#v", got[0])
+	}
+}
+
+func TestDecodeBadMagic(t *testing.T) {
+	_, err := Decode([]byte("nope"))
+	if err == nil {
+		t.Fatal("expected error for bad magic")
+	}
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	events := []Event{
+		{Key: XK_A, Action: ActionTap},
+		{Key: XK_B, Action: ActionTap, Repeat: 2},
+	}
+
+	buf := &bytes.Buffer{}
+	var ws Stream
+	for _, e := range events {
+		if err := ws.WriteEvent(buf, e); err != nil {
+			t.Fatalf("WriteEvent: %v", err)
+		}
+	}
+
+	var rs Stream
+	for i, want := range events {
+		got, err := rs.ReadEvent(buf)
+		if err != nil {
+			t.Fatalf("ReadEvent %d: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("event %d: e %#v, r %#v", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package ir
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// XK_Compose is the synthetic "Compose" (a.k.a. Multi_key) key used to
+// introduce a dead-key / compose sequence, e.g. Compose, ', e for "é".
+// XK_DeadDiaeresis stands in for the diaeresis dead key: the diaeresis
+// accent has no literal key of its own distinct from the acute accent's
+// (both would otherwise have to reuse XK_Quote, the closest literal key,
+// colliding whenever they're combined with the same letter, e.g. á/ä).
+// Neither has a mac/windows/x11 native equivalent or Keymap mapping;
+// they only ever appear inside a ComposeTable sequence.
+const (
+	XK_Compose = 0x1000 + iota
+	XK_DeadDiaeresis
+)
+
+// ComposeTable maps a rune outside stringMap's coverage to the key
+// sequence (starting with XK_Compose) that types it. See LoadCompose to
+// load one from a standard XCompose file.
+type ComposeTable map[rune][]int
+
+// DefaultCompose covers the most common Latin-1 diacritics and symbols.
+// Where a literal key exists, it's used directly: ' for acute, ` for
+// grave/tilde, , for cedilla. Diaeresis has no literal key distinct from
+// acute's (both would map to XK_Quote), so it uses the synthetic
+// XK_DeadDiaeresis instead, keeping every entry's sequence unique so
+// ToString can invert the table unambiguously. It is intentionally
+// small; load a full table with LoadCompose if more coverage is needed.
+var DefaultCompose = ComposeTable{
+	'é': {XK_Compose, XK_Quote, XK_E},
+	'è': {XK_Compose, XK_GRAVE, XK_E},
+	'á': {XK_Compose, XK_Quote, XK_A},
+	'à': {XK_Compose, XK_GRAVE, XK_A},
+	'ñ': {XK_Compose, XK_GRAVE, XK_N},
+	'ü': {XK_Compose, XK_DeadDiaeresis, XK_U},
+	'ö': {XK_Compose, XK_DeadDiaeresis, XK_O},
+	'ä': {XK_Compose, XK_DeadDiaeresis, XK_A},
+	'ç': {XK_Compose, XK_COMMA, XK_C},
+	'ß': {XK_Compose, XK_S, XK_S},
+	'€': {XK_Compose, XK_C, XK_EQUAL},
+	'£': {XK_Compose, XK_L, XK_MINUS},
+	'©': {XK_Compose, XK_O, XK_C},
+}
+
+// Compose is the table ToKeys/ToString fall back to for runes not found
+// in stringMap. It defaults to DefaultCompose; assign a table loaded by
+// LoadCompose (or a custom one) to change it.
+var Compose = DefaultCompose
+
+// maxLen returns the length of the longest sequence in the table, used
+// to bound the lookahead ToString does when decoding compose sequences.
+func (t ComposeTable) maxLen() int {
+	n := 0
+	for _, seq := range t {
+		if len(seq) > n {
+			n = len(seq)
+		}
+	}
+	return n
+}
+
+// lookup returns the rune produced by keys[:n] for some n, and that n,
+// preferring the longest match, or ok == false if no sequence matches.
+func (t ComposeTable) lookup(keys []int) (r rune, n int, ok bool) {
+	max := t.maxLen()
+	if max > len(keys) {
+		max = len(keys)
+	}
+	for n := max; n > 0; n-- {
+		for run, seq := range t {
+			if len(seq) != n {
+				continue
+			}
+			if intsEqual(seq, keys[:n]) {
+				return run, n, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func intsEqual(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// composeSymbols resolves the <name> tokens used by XCompose files that
+// don't correspond directly to a single printable rune. Most dead_*
+// names are mapped to the literal key DefaultCompose uses for the same
+// accent (see its doc comment); dead_diaeresis maps to XK_DeadDiaeresis
+// since diaeresis has no literal key of its own.
+var composeSymbols = map[string]int{
+	"multi_key":      XK_Compose,
+	"dead_acute":     XK_Quote,
+	"dead_grave":     XK_GRAVE,
+	"dead_tilde":     XK_GRAVE,
+	"dead_diaeresis": XK_DeadDiaeresis,
+	"dead_cedilla":   XK_COMMA,
+	"minus":          XK_MINUS,
+	"equal":          XK_EQUAL,
+	"period":         XK_Period,
+	"comma":          XK_COMMA,
+	"slash":          XK_SLASH,
+	"apostrophe":     XK_Quote,
+	"grave":          XK_GRAVE,
+	"semicolon":      XK_SEMICOLON,
+	"backslash":      XK_BACKSLASH,
+}
+
+// LoadCompose parses an XCompose-format file (as used by libX11/IBus),
+// e.g. a line `<dead_acute> <e> : "é"`, into a ComposeTable. Lines that
+// don't resolve to a single-rune result are skipped; `#` starts a
+// comment.
+func LoadCompose(path string) (ComposeTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	table := make(ComposeTable)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		sep := strings.IndexByte(line, ':')
+		if sep < 0 {
+			return nil, fmt.Errorf("ir: %s:%d: missing ':'", path, lineNo)
+		}
+		lhs, rhs := line[:sep], line[sep+1:]
+		seq, err := parseComposeTokens(lhs)
+		if err != nil {
+			return nil, fmt.Errorf("ir: %s:%d: %v", path, lineNo, err)
+		}
+		if len(seq) > 0 && seq[0] != XK_Compose {
+			// A dead-key line (<dead_acute> <e>) gets the same leading
+			// XK_Compose DefaultCompose's entries have.
+			seq = append([]int{XK_Compose}, seq...)
+		}
+		value, ok := extractQuoted(rhs)
+		if !ok {
+			continue // no quoted result, e.g. a bare keysym name: skip
+		}
+		runes := []rune(value)
+		if len(runes) != 1 {
+			continue // multi-rune results aren't representable as a ComposeTable key
+		}
+		table[runes[0]] = seq
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// parseComposeTokens parses a run of "<name> <name> ..." tokens into key
+// codes, resolving names found in composeSymbols first and any other
+// single-character name through runeToKey (the active layout, then
+// stringMap); the modifiers runeToKey reports are dropped.
+func parseComposeTokens(s string) ([]int, error) {
+	var keys []int
+	i := 0
+	for i < len(s) {
+		if s[i] != '<' {
+			i++
+			continue
+		}
+		end := strings.IndexByte(s[i:], '>')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated '<' in %q", s)
+		}
+		name := strings.ToLower(s[i+1 : i+end])
+		i += end + 1
+
+		if key, ok := composeSymbols[name]; ok {
+			keys = append(keys, key)
+			continue
+		}
+		if r := []rune(name); len(r) == 1 {
+			key, _, err := runeToKey(r[0])
+			if err != nil {
+				return nil, fmt.Errorf("unknown compose symbol %q", name)
+			}
+			keys = append(keys, key)
+			continue
+		}
+		return nil, fmt.Errorf("unknown compose symbol %q", name)
+	}
+	return keys, nil
+}
+
+// extractQuoted returns the contents of the first "..." in s.
+func extractQuoted(s string) (string, bool) {
+	start := strings.IndexByte(s, '"')
+	if start < 0 {
+		return "", false
+	}
+	end := strings.IndexByte(s[start+1:], '"')
+	if end < 0 {
+		return "", false
+	}
+	return s[start+1 : start+1+end], true
+}
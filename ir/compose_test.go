@@ -0,0 +1,40 @@
+package ir
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToKeysComposeFallback(t *testing.T) {
+	keys, err := ToKeys("é")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	want := []int{XK_Compose, XK_Quote, XK_E}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("e %#v, r %#v", want, keys)
+	}
+}
+
+func TestComposeRoundTrip(t *testing.T) {
+	for _, r := range []rune{'é', 'è', 'á', 'à', 'ñ', 'ü', 'ö', 'ä', 'ç', 'ß', '€', '£', '©'} {
+		keys, err := ToKeys(string(r))
+		if err != nil {
+			t.Fatalf("ToKeys(%q): %v", r, err)
+		}
+		s, err := ToString(keys)
+		if err != nil {
+			t.Fatalf("ToString(%v): %v", keys, err)
+		}
+		if s != string(r) {
+			t.Errorf("round trip: got %q, want %q", s, string(r))
+		}
+	}
+}
+
+func TestUnsupportedRuneError(t *testing.T) {
+	_, err := ToKeys("漢")
+	if err == nil {
+		t.Fatal("expected error for rune with no key or compose mapping")
+	}
+}
@@ -0,0 +1,213 @@
+// Package hid turns an ir.Event sequence into standard USB HID
+// boot-keyboard input reports, so this package can sit behind a BLE HID
+// peripheral (e.g. tinygo.org/x/bluetooth) or a USB gadget-mode device.
+// The HID usage table is ir.IRKey, the canonical IR already used to
+// translate between platform keymaps (see the Keymap types in ir).
+package hid
+
+import (
+	"fmt"
+
+	"github.com/themihai/keybd_event/ir"
+)
+
+// Modifier bits, as defined by the USB HID boot-keyboard input report
+// (the first byte of Report's 8).
+const (
+	modLeftControl byte = 1 << iota
+	modLeftShift
+	modLeftAlt
+	modLeftGUI
+	modRightControl
+	modRightShift
+	modRightAlt
+	modRightGUI
+)
+
+// errorRollOver is the HID "phantom state" keycode (0x01) reports use to
+// fill all six key slots when more than six keys are held at once, since
+// a boot-protocol report can't represent more than six simultaneously.
+const errorRollOver = 0x01
+
+// maxKeys is how many simultaneous non-modifier keys a boot-keyboard
+// report can carry.
+const maxKeys = 6
+
+// modifierEvent maps the native mac keycodes ir.Event.Key can hold for an
+// explicit modifier press/release (see ir.Parse's "{ctrl down}" syntax)
+// to the report's modifier bit.
+var modifierEvent = map[int]byte{
+	ir.XK_Control:      modLeftControl,
+	ir.XK_RightControl: modRightControl,
+	ir.XK_Shift:        modLeftShift,
+	ir.XK_RightShift:   modRightShift,
+	ir.XK_Option:       modLeftAlt,
+	ir.XK_ALT:          modLeftAlt,
+	ir.XK_RightOption:  modRightAlt,
+	ir.XK_Command:      modLeftGUI,
+}
+
+func modsByte(m ir.Modifier) byte {
+	var b byte
+	if m&ir.ModCtrl != 0 {
+		b |= modLeftControl
+	}
+	if m&ir.ModShift != 0 {
+		b |= modLeftShift
+	}
+	if m&ir.ModAlt != 0 {
+		b |= modLeftAlt
+	}
+	if m&ir.ModCmd != 0 {
+		b |= modLeftGUI
+	}
+	return b
+}
+
+// state tracks what a real keyboard would currently be holding down, so
+// Report can emit the down/up transitions a boot-keyboard report needs.
+type state struct {
+	mods byte
+	keys []ir.IRKey // currently held, in press order
+}
+
+func (s *state) report() [8]byte {
+	var r [8]byte
+	r[0] = s.mods
+	if len(s.keys) > maxKeys {
+		for i := 2; i < 8; i++ {
+			r[i] = errorRollOver
+		}
+		return r
+	}
+	for i, k := range s.keys {
+		r[2+i] = byte(k)
+	}
+	return r
+}
+
+func (s *state) press(k ir.IRKey) {
+	for _, held := range s.keys {
+		if held == k {
+			return
+		}
+	}
+	s.keys = append(s.keys, k)
+}
+
+func (s *state) release(k ir.IRKey) {
+	for i, held := range s.keys {
+		if held == k {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// Report expands events into the stream of 8-byte USB HID keyboard input
+// reports a real keyboard would send: modifier byte, a reserved zero
+// byte, then up to six simultaneously held key usage IDs.
+//
+// An ir.ActionTap event presses then immediately releases its key, so a
+// release report (back to whatever was already held, all zero when
+// nothing else is) always separates it from whatever comes next --
+// including a repeat of the very same key, which a real
+// keyboard (and most OSes) require to see released before it will
+// register as pressed again. ir.ActionPress/ir.ActionRelease hold or
+// release a key (or, for the modifier keycodes ir.Parse's "down"/"up"
+// syntax produces, a modifier bit) across later events, enabling chords.
+// More than six simultaneously held non-modifier keys produce the HID
+// "phantom state" (all-0x01) report rather than a silently dropped key.
+func Report(events []ir.Event) ([][8]byte, error) {
+	var reports [][8]byte
+	var s state
+
+	do := func(e ir.Event) error {
+		if mbit, ok := modifierEvent[e.Key]; ok && e.Action != ir.ActionTap {
+			if e.Action == ir.ActionPress {
+				s.mods |= mbit
+			} else {
+				s.mods &^= mbit
+			}
+			reports = append(reports, s.report())
+			return nil
+		}
+		if e.IsDelay {
+			return nil // pure delay marker, no report to emit
+		}
+		key, err := ir.MacKeymap.ToIR(e.Key)
+		if err != nil {
+			return fmt.Errorf("hid: %v", err)
+		}
+		eventMods := s.mods | modsByte(e.Mods)
+		switch e.Action {
+		case ir.ActionPress:
+			s.mods = eventMods
+			s.press(key)
+			reports = append(reports, s.report())
+		case ir.ActionRelease:
+			s.release(key)
+			s.mods = eventMods
+			reports = append(reports, s.report())
+		default: // ActionTap
+			down := state{mods: eventMods, keys: append(append([]ir.IRKey{}, s.keys...), key)}
+			reports = append(reports, down.report())
+			reports = append(reports, s.report()) // release: back to whatever was already held
+		}
+		return nil
+	}
+
+	for _, e := range events {
+		n := e.Repeat
+		if n < 1 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			if err := do(e); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return reports, nil
+}
+
+// ReportDescriptor returns the standard USB HID boot-protocol keyboard
+// report descriptor (USB HID spec, Appendix B.1), so this package's
+// reports can be used directly by a gadget-mode USB device or a BLE HID
+// peripheral without writing a custom descriptor.
+func ReportDescriptor() []byte {
+	return []byte{
+		0x05, 0x01, // Usage Page (Generic Desktop)
+		0x09, 0x06, // Usage (Keyboard)
+		0xA1, 0x01, // Collection (Application)
+		0x05, 0x07, //   Usage Page (Key Codes)
+		0x19, 0xE0, //   Usage Minimum (224)
+		0x29, 0xE7, //   Usage Maximum (231)
+		0x15, 0x00, //   Logical Minimum (0)
+		0x25, 0x01, //   Logical Maximum (1)
+		0x75, 0x01, //   Report Size (1)
+		0x95, 0x08, //   Report Count (8)
+		0x81, 0x02, //   Input (Data, Variable, Absolute): modifier byte
+		0x95, 0x01, //   Report Count (1)
+		0x75, 0x08, //   Report Size (8)
+		0x81, 0x01, //   Input (Constant): reserved byte
+		0x95, 0x05, //   Report Count (5)
+		0x75, 0x01, //   Report Size (1)
+		0x05, 0x08, //   Usage Page (LEDs)
+		0x19, 0x01, //   Usage Minimum (1)
+		0x29, 0x05, //   Usage Maximum (5)
+		0x91, 0x02, //   Output (Data, Variable, Absolute): LED report
+		0x95, 0x01, //   Report Count (1)
+		0x75, 0x03, //   Report Size (3)
+		0x91, 0x01, //   Output (Constant): LED report padding
+		0x95, 0x06, //   Report Count (6)
+		0x75, 0x08, //   Report Size (8)
+		0x15, 0x00, //   Logical Minimum (0)
+		0x25, 0x65, //   Logical Maximum (101)
+		0x05, 0x07, //   Usage Page (Key Codes)
+		0x19, 0x00, //   Usage Minimum (0)
+		0x29, 0x65, //   Usage Maximum (101)
+		0x81, 0x00, //   Input (Data, Array)
+		0xC0, // End Collection
+	}
+}
@@ -0,0 +1,101 @@
+package hid
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/themihai/keybd_event/ir"
+)
+
+func TestReportTap(t *testing.T) {
+	reports, err := Report([]ir.Event{
+		{Key: ir.XK_A, Rune: 'a', Action: ir.ActionTap},
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	want := [][8]byte{
+		{0, 0, byte(ir.HID_A), 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("e %#v, r %#v", want, reports)
+	}
+}
+
+func TestReportHeldModifierAcrossTap(t *testing.T) {
+	reports, err := Report([]ir.Event{
+		{Key: ir.XK_Control, Action: ir.ActionPress},
+		{Key: ir.XK_A, Rune: 'a', Action: ir.ActionTap},
+		{Key: ir.XK_Control, Action: ir.ActionRelease},
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	if len(reports) != 4 {
+		t.Fatalf("expected 4 reports, got %d: %#v", len(reports), reports)
+	}
+	if reports[0][0] != modLeftControl {
+		t.Errorf("ctrl-down report should set the modifier byte, got %#v", reports[0])
+	}
+	if reports[1][0] != modLeftControl || reports[1][2] != byte(ir.HID_A) {
+		t.Errorf("a-down report should keep ctrl held, got %#v", reports[1])
+	}
+	if reports[2][0] != modLeftControl || reports[2][2] != 0 {
+		t.Errorf("a-release report should keep ctrl held and clear the key, got %#v", reports[2])
+	}
+	if reports[3] != ([8]byte{}) {
+		t.Errorf("ctrl-up report should be all zero, got %#v", reports[3])
+	}
+}
+
+func TestReportRepeatInsertsReleaseBetween(t *testing.T) {
+	reports, err := Report([]ir.Event{
+		{Key: ir.XK_TAB, Action: ir.ActionTap, Repeat: 2},
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	if len(reports) != 4 {
+		t.Fatalf("expected 4 reports (down/up x2), got %d", len(reports))
+	}
+	for i, want := range []bool{true, false, true, false} {
+		pressed := reports[i][2] == byte(ir.HID_TAB)
+		if pressed != want {
+			t.Errorf("report %d: expected pressed=%v, got %#v", i, want, reports[i])
+		}
+	}
+}
+
+func TestReportRollOver(t *testing.T) {
+	var events []ir.Event
+	for _, k := range []int{ir.XK_A, ir.XK_S, ir.XK_D, ir.XK_F, ir.XK_G, ir.XK_H, ir.XK_J} {
+		events = append(events, ir.Event{Key: k, Action: ir.ActionPress})
+	}
+	reports, err := Report(events)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	last := reports[len(reports)-1]
+	for _, b := range last[2:] {
+		if b != errorRollOver {
+			t.Errorf("expected phantom state on 7th held key, got %#v", last)
+			break
+		}
+	}
+}
+
+func TestReportDescriptorStartsWithKeyboardCollection(t *testing.T) {
+	d := ReportDescriptor()
+	if len(d) == 0 {
+		t.Fatal("expected a non-empty report descriptor")
+	}
+	// Usage Page (Generic Desktop), Usage (Keyboard), Collection (Application)
+	start := []byte{0x05, 0x01, 0x09, 0x06, 0xA1, 0x01}
+	if len(d) < len(start) || !reflect.DeepEqual(d[:len(start)], start) {
+		t.Errorf("expected descriptor to open a keyboard application collection, got %#v", d)
+	}
+	if d[len(d)-1] != 0xC0 {
+		t.Errorf("expected descriptor to end with End Collection (0xC0), got %#x", d[len(d)-1])
+	}
+}
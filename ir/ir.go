@@ -8,7 +8,6 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
-	"unicode"
 )
 
 const (
@@ -202,12 +201,30 @@ var stringMap = map[int]string{
 }
 
 // Special characters are ignored?
-func ToString(keys []int) (string, error) {
+//
+// km is optional and defaults to MacKeymap; when given, keys are assumed
+// to be native codes in km's registry and are translated to mac codes
+// (the registry stringMap is keyed by) before decoding.
+func ToString(keys []int, km ...Keymap) (string, error) {
+	if len(km) > 0 && km[0].Name() != MacKeymap.Name() {
+		translated, err := Translate(km[0], MacKeymap, keys)
+		if err != nil {
+			return "", err
+		}
+		keys = translated
+	}
 	buf := &bytes.Buffer{}
 	var is = struct {
 		shift bool
 	}{}
-	for _, key := range keys {
+	for i := 0; i < len(keys); {
+		if r, n, ok := Compose.lookup(keys[i:]); ok {
+			buf.WriteRune(r)
+			i += n
+			continue
+		}
+		key := keys[i]
+		i++
 		ks, ok := stringMap[key]
 		if !ok {
 			return "", fmt.Errorf("Key %v not found", key)
@@ -235,27 +252,24 @@ func ToString(keys []int) (string, error) {
 	return buf.String(), nil
 }
 
-// Attempts to convert strings to the key code
-// sequence required to construct them in an input field
-func ToKeys(s string) ([]int, error) {
-	var keys []int
-	for _, v := range s {
-		var key int
-		var found bool
-		for k, ks := range stringMap {
-			if strings.EqualFold(ks, string(v)) {
-				key = k
-				found = true
-				break
-			}
-		}
-		switch {
-		case found == false:
-			return nil, fmt.Errorf("key for rune %v (hex: %+q) not found, s  %s", v, v, s)
-		case unicode.IsUpper(v):
-			keys = append(keys, XK_Shift)
-		}
-		keys = append(keys, key)
+// Attempts to convert strings to the key code sequence required to
+// construct them in an input field. s may also be a chord/sequence
+// description understood by Parse, e.g. "ctrl+shift+k" or
+// "{enter}hello{space}world"; ToKeys is a thin wrapper that parses s and
+// flattens the resulting Events into a plain []int, for back-compat with
+// callers that predate Event.
+//
+// km is optional and defaults to MacKeymap; when given, the returned
+// keys are translated from mac codes (the registry stringMap is keyed
+// by) to km's registry, so the sequence can be replayed on that platform.
+func ToKeys(s string, km ...Keymap) ([]int, error) {
+	events, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	keys := flatten(events)
+	if len(km) > 0 && km[0].Name() != MacKeymap.Name() {
+		return Translate(MacKeymap, km[0], keys)
 	}
 	return keys, nil
 }
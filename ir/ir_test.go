@@ -13,11 +13,14 @@ func TestToKeys(t *testing.T) {
 
 		{
 			in:  "xYz",
-			out: []int{0, 1},
+			out: []int{XK_X, XK_Shift, XK_Y, XK_Z},
 		},
 		{
-			in:  "Hello World",
-			out: []int{0, 1},
+			in: "Hello World",
+			out: []int{
+				XK_Shift, XK_H, XK_E, XK_L, XK_L, XK_O, XK_SPACE,
+				XK_Shift, XK_W, XK_O, XK_R, XK_L, XK_D,
+			},
 		},
 	}
 
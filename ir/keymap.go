@@ -0,0 +1,422 @@
+package ir
+
+import "fmt"
+
+// IRKey is the canonical, OS-neutral key representation. It is a USB HID
+// keyboard/keypad usage ID (USB HID Usage Tables, page 0x07) since that
+// registry is well defined, stable, and already shared by BLE HID and
+// QMK-style keyboards. Every other registry in this package (mac virtual
+// keycodes, Windows VK_*, X11 keysyms) translates to and from IRKey.
+type IRKey uint16
+
+// HID usage IDs for the keyboard/keypad page. Only a subset is named:
+// the keys the mac XK_* keycodes cover, plus the eight modifiers.
+// HIDKeymap passes any usage ID through unchanged, named or not;
+// MacKeymap, WinKeymap and X11Keymap translate only the usages in their
+// tables.
+const (
+	HID_A IRKey = 0x04 + iota
+	HID_B
+	HID_C
+	HID_D
+	HID_E
+	HID_F
+	HID_G
+	HID_H
+	HID_I
+	HID_J
+	HID_K
+	HID_L
+	HID_M
+	HID_N
+	HID_O
+	HID_P
+	HID_Q
+	HID_R
+	HID_S
+	HID_T
+	HID_U
+	HID_V
+	HID_W
+	HID_X
+	HID_Y
+	HID_Z
+	HID_1
+	HID_2
+	HID_3
+	HID_4
+	HID_5
+	HID_6
+	HID_7
+	HID_8
+	HID_9
+	HID_0
+	HID_ENTER
+	HID_ESC
+	HID_DELETE // backspace
+	HID_TAB
+	HID_SPACE
+	HID_MINUS
+	HID_EQUAL
+	HID_LeftBracket
+	HID_RightBracket
+	HID_BACKSLASH
+	_ // non-US # (unused)
+	HID_SEMICOLON
+	HID_Quote
+	HID_GRAVE
+	HID_COMMA
+	HID_Period
+	HID_SLASH
+	HID_CAPSLOCK
+	HID_F1
+	HID_F2
+	HID_F3
+	HID_F4
+	HID_F5
+	HID_F6
+	HID_F7
+	HID_F8
+	HID_F9
+	HID_F10
+	HID_F11
+	HID_F12
+)
+
+// Keys without a contiguous HID run above get explicit usage IDs.
+const (
+	HID_HOME         IRKey = 0x4A
+	HID_PAGEUP       IRKey = 0x4B
+	HID_ForwardDelete IRKey = 0x4C
+	HID_END          IRKey = 0x4D
+	HID_PAGEDOWN     IRKey = 0x4E
+	HID_RIGHT        IRKey = 0x4F
+	HID_LEFT         IRKey = 0x50
+	HID_DOWN         IRKey = 0x51
+	HID_UP           IRKey = 0x52
+
+	HID_KeypadDivide   IRKey = 0x54
+	HID_KeypadMultiply IRKey = 0x55
+	HID_KeypadMinus    IRKey = 0x56
+	HID_KeypadPlus     IRKey = 0x57
+	HID_KeypadEnter    IRKey = 0x58
+	HID_Keypad1        IRKey = 0x59
+	HID_Keypad2        IRKey = 0x5A
+	HID_Keypad3        IRKey = 0x5B
+	HID_Keypad4        IRKey = 0x5C
+	HID_Keypad5        IRKey = 0x5D
+	HID_Keypad6        IRKey = 0x5E
+	HID_Keypad7        IRKey = 0x5F
+	HID_Keypad8        IRKey = 0x60
+	HID_Keypad9        IRKey = 0x61
+	HID_Keypad0        IRKey = 0x62
+	HID_KeypadDecimal  IRKey = 0x63
+	HID_KeypadEquals   IRKey = 0x67
+
+	HID_F13 IRKey = 0x68
+	HID_F14 IRKey = 0x69
+	HID_F15 IRKey = 0x6A
+	HID_F16 IRKey = 0x6B
+	HID_F17 IRKey = 0x6C
+	HID_F18 IRKey = 0x6D
+	HID_F19 IRKey = 0x6E
+	HID_F20 IRKey = 0x6F
+
+	HID_LeftControl  IRKey = 0xE0
+	HID_LeftShift    IRKey = 0xE1
+	HID_LeftAlt      IRKey = 0xE2
+	HID_LeftGUI      IRKey = 0xE3
+	HID_RightControl IRKey = 0xE4
+	HID_RightShift   IRKey = 0xE5
+	HID_RightAlt     IRKey = 0xE6
+	HID_RightGUI     IRKey = 0xE7
+)
+
+// Keymap translates between a platform-native keycode and the canonical
+// IR (see IRKey). A key captured on one platform can be shipped as IR
+// and replayed on another by going native -> ToIR -> FromIR -> native.
+type Keymap interface {
+	// ToIR converts a native keycode to its IR representation.
+	ToIR(native int) (IRKey, error)
+	// FromIR converts an IR key back to this platform's native keycode.
+	FromIR(k IRKey) (int, error)
+	// Name identifies the keymap, e.g. "mac", "windows", "x11", "hid".
+	Name() string
+}
+
+// tableKeymap is a Keymap backed by a pair of lookup maps. It is the
+// implementation behind MacKeymap, WinKeymap, X11Keymap and HIDKeymap.
+type tableKeymap struct {
+	name     string
+	toIR     map[int]IRKey
+	fromIR   map[IRKey]int
+}
+
+func newTableKeymap(name string, toIR map[int]IRKey) *tableKeymap {
+	fromIR := make(map[IRKey]int, len(toIR))
+	for native, ir := range toIR {
+		fromIR[ir] = native
+	}
+	return &tableKeymap{name: name, toIR: toIR, fromIR: fromIR}
+}
+
+func (t *tableKeymap) ToIR(native int) (IRKey, error) {
+	ir, ok := t.toIR[native]
+	if !ok {
+		return 0, fmt.Errorf("ir: %s keymap has no IR mapping for native code %#x", t.name, native)
+	}
+	return ir, nil
+}
+
+func (t *tableKeymap) FromIR(k IRKey) (int, error) {
+	native, ok := t.fromIR[k]
+	if !ok {
+		return 0, fmt.Errorf("ir: %s keymap has no native code for IR key %#x", t.name, k)
+	}
+	return native, nil
+}
+
+func (t *tableKeymap) Name() string { return t.name }
+
+// macToHID maps this package's existing mac virtual keycodes (the XK_*
+// constants in ir.go) to the canonical HID usage IDs.
+var macToHID = map[int]IRKey{
+	XK_A: HID_A, XK_B: HID_B, XK_C: HID_C, XK_D: HID_D, XK_E: HID_E,
+	XK_F: HID_F, XK_G: HID_G, XK_H: HID_H, XK_I: HID_I, XK_J: HID_J,
+	XK_K: HID_K, XK_L: HID_L, XK_M: HID_M, XK_N: HID_N, XK_O: HID_O,
+	XK_P: HID_P, XK_Q: HID_Q, XK_R: HID_R, XK_S: HID_S, XK_T: HID_T,
+	XK_U: HID_U, XK_V: HID_V, XK_W: HID_W, XK_X: HID_X, XK_Y: HID_Y,
+	XK_Z: HID_Z,
+
+	XK_0: HID_0, XK_1: HID_1, XK_2: HID_2, XK_3: HID_3, XK_4: HID_4,
+	XK_5: HID_5, XK_6: HID_6, XK_7: HID_7, XK_8: HID_8, XK_9: HID_9,
+
+	XK_ENTER: HID_ENTER, XK_ESC: HID_ESC, XK_DELETE: HID_DELETE,
+	XK_TAB: HID_TAB, XK_SPACE: HID_SPACE, XK_MINUS: HID_MINUS,
+	XK_EQUAL: HID_EQUAL, XK_LeftBracket: HID_LeftBracket,
+	XK_RightBracket: HID_RightBracket, XK_BACKSLASH: HID_BACKSLASH,
+	XK_SEMICOLON: HID_SEMICOLON, XK_Quote: HID_Quote, XK_GRAVE: HID_GRAVE,
+	XK_COMMA: HID_COMMA, XK_Period: HID_Period, XK_SLASH: HID_SLASH,
+	XK_CAPSLOCK: HID_CAPSLOCK,
+
+	XK_F1: HID_F1, XK_F2: HID_F2, XK_F3: HID_F3, XK_F4: HID_F4,
+	XK_F5: HID_F5, XK_F6: HID_F6, XK_F7: HID_F7, XK_F8: HID_F8,
+	XK_F9: HID_F9, XK_F10: HID_F10, XK_F11: HID_F11, XK_F12: HID_F12,
+	XK_F13: HID_F13, XK_F14: HID_F14, XK_F15: HID_F15, XK_F16: HID_F16,
+	XK_F17: HID_F17, XK_F18: HID_F18, XK_F19: HID_F19, XK_F20: HID_F20,
+
+	XK_HOME: HID_HOME, XK_PAGEUP: HID_PAGEUP, XK_ForwardDelete: HID_ForwardDelete,
+	XK_END: HID_END, XK_PAGEDOWN: HID_PAGEDOWN,
+	XK_LEFT: HID_LEFT, XK_RIGHT: HID_RIGHT, XK_DOWN: HID_DOWN, XK_UP: HID_UP,
+
+	XK_KeypadDivide: HID_KeypadDivide, XK_KeypadMultiply: HID_KeypadMultiply,
+	XK_KeypadMinus: HID_KeypadMinus, XK_KeypadPlus: HID_KeypadPlus,
+	XK_KeypadEnter: HID_KeypadEnter, XK_KeypadEquals: HID_KeypadEquals,
+	XK_KeypadDecimal: HID_KeypadDecimal,
+	XK_Keypad0:       HID_Keypad0, XK_Keypad1: HID_Keypad1, XK_Keypad2: HID_Keypad2,
+	XK_Keypad3: HID_Keypad3, XK_Keypad4: HID_Keypad4, XK_Keypad5: HID_Keypad5,
+	XK_Keypad6: HID_Keypad6, XK_Keypad7: HID_Keypad7, XK_Keypad8: HID_Keypad8,
+	XK_Keypad9: HID_Keypad9,
+
+	XK_Control: HID_LeftControl, XK_RightControl: HID_RightControl,
+	XK_Shift: HID_LeftShift, XK_RightShift: HID_RightShift,
+	XK_Option: HID_LeftAlt, XK_RightOption: HID_RightAlt,
+	XK_Command: HID_LeftGUI,
+}
+
+// Windows virtual-key codes (WinAPI WM_KEYDOWN wParam values).
+const (
+	VK_BACK   = 0x08
+	VK_TAB    = 0x09
+	VK_RETURN = 0x0D
+	VK_SHIFT  = 0x10
+	VK_CONTROL = 0x11
+	VK_MENU   = 0x12 // Alt
+	VK_ESCAPE = 0x1B
+	VK_SPACE  = 0x20
+	VK_PRIOR  = 0x21 // Page Up
+	VK_NEXT   = 0x22 // Page Down
+	VK_END    = 0x23
+	VK_HOME   = 0x24
+	VK_LEFT   = 0x25
+	VK_UP     = 0x26
+	VK_RIGHT  = 0x27
+	VK_DOWN   = 0x28
+	VK_DELETE = 0x2E
+	// '0'..'9' and 'A'..'Z' match their ASCII codes on Windows.
+	VK_0 = '0'
+	VK_A = 'A'
+	VK_OEM_MINUS  = 0xBD
+	VK_OEM_PLUS   = 0xBB
+	VK_OEM_4      = 0xDB // [
+	VK_OEM_6      = 0xDD // ]
+	VK_OEM_5      = 0xDC // backslash
+	VK_OEM_1      = 0xBA // ;
+	VK_OEM_7      = 0xDE // '
+	VK_OEM_3      = 0xC0 // `
+	VK_OEM_COMMA  = 0xBC
+	VK_OEM_PERIOD = 0xBE
+	VK_OEM_2      = 0xBF // /
+	VK_CAPITAL    = 0x14
+	VK_F1         = 0x70
+	VK_LWIN       = 0x5B
+	VK_LCONTROL   = 0xA2
+	VK_RCONTROL   = 0xA3
+	VK_LSHIFT     = 0xA0
+	VK_RSHIFT     = 0xA1
+	VK_LMENU      = 0xA4
+	VK_RMENU      = 0xA5
+)
+
+// hidDigit returns the HID usage ID for the digit key '0'+i (i in
+// [0,9]). The usage table does not run HID_0, HID_0+1, ...: per the USB
+// HID Usage Tables, the top-row digits are ordered HID_1..HID_9, HID_0
+// (see the const block above), so a contiguous offset from HID_0 is
+// wrong for every digit but 0.
+func hidDigit(i int) IRKey {
+	if i == 0 {
+		return HID_0
+	}
+	return HID_1 + IRKey(i-1)
+}
+
+var winToHID = func() map[int]IRKey {
+	m := map[int]IRKey{
+		VK_BACK: HID_DELETE, VK_TAB: HID_TAB, VK_RETURN: HID_ENTER,
+		VK_ESCAPE: HID_ESC, VK_SPACE: HID_SPACE,
+		VK_PRIOR: HID_PAGEUP, VK_NEXT: HID_PAGEDOWN, VK_END: HID_END,
+		VK_HOME: HID_HOME, VK_LEFT: HID_LEFT, VK_UP: HID_UP,
+		VK_RIGHT: HID_RIGHT, VK_DOWN: HID_DOWN, VK_DELETE: HID_ForwardDelete,
+		VK_OEM_MINUS: HID_MINUS, VK_OEM_PLUS: HID_EQUAL,
+		VK_OEM_4: HID_LeftBracket, VK_OEM_6: HID_RightBracket,
+		VK_OEM_5: HID_BACKSLASH, VK_OEM_1: HID_SEMICOLON, VK_OEM_7: HID_Quote,
+		VK_OEM_3: HID_GRAVE, VK_OEM_COMMA: HID_COMMA, VK_OEM_PERIOD: HID_Period,
+		VK_OEM_2: HID_SLASH, VK_CAPITAL: HID_CAPSLOCK,
+		VK_LWIN: HID_LeftGUI,
+		VK_LCONTROL: HID_LeftControl, VK_RCONTROL: HID_RightControl,
+		VK_LSHIFT: HID_LeftShift, VK_RSHIFT: HID_RightShift,
+		VK_LMENU: HID_LeftAlt, VK_RMENU: HID_RightAlt,
+	}
+	for i := 0; i < 10; i++ {
+		m[VK_0+i] = hidDigit(i)
+	}
+	for i := 0; i < 26; i++ {
+		m[VK_A+i] = HID_A + IRKey(i)
+	}
+	for i := 0; i < 12; i++ {
+		m[VK_F1+i] = HID_F1 + IRKey(i)
+	}
+	return m
+}()
+
+// X11/X Window System keysyms (see X11/keysymdef.h). Only the subset
+// needed to round-trip with stringMap is listed.
+const (
+	XK_KEY_BackSpace = 0xFF08
+	XK_KEY_Tab       = 0xFF09
+	XK_KEY_Return    = 0xFF0D
+	XK_KEY_Escape    = 0xFF1B
+	XK_KEY_space     = 0x0020
+	XK_KEY_Home      = 0xFF50
+	XK_KEY_Left      = 0xFF51
+	XK_KEY_Up        = 0xFF52
+	XK_KEY_Right     = 0xFF53
+	XK_KEY_Down      = 0xFF54
+	XK_KEY_Page_Up   = 0xFF55
+	XK_KEY_Page_Down = 0xFF56
+	XK_KEY_End       = 0xFF57
+	XK_KEY_Delete    = 0xFFFF
+	XK_KEY_Shift_L   = 0xFFE1
+	XK_KEY_Shift_R   = 0xFFE2
+	XK_KEY_Control_L = 0xFFE3
+	XK_KEY_Control_R = 0xFFE4
+	XK_KEY_Alt_L     = 0xFFE9
+	XK_KEY_Alt_R     = 0xFFEA
+	XK_KEY_Super_L   = 0xFFEB
+	XK_KEY_0         = 0x0030
+	XK_KEY_a         = 0x0061
+	XK_KEY_minus     = 0x002D
+	XK_KEY_equal     = 0x003D
+	XK_KEY_bracketleft  = 0x005B
+	XK_KEY_bracketright = 0x005D
+	XK_KEY_backslash    = 0x005C
+	XK_KEY_semicolon    = 0x003B
+	XK_KEY_apostrophe   = 0x0027
+	XK_KEY_grave        = 0x0060
+	XK_KEY_comma        = 0x002C
+	XK_KEY_period       = 0x002E
+	XK_KEY_slash        = 0x002F
+	XK_KEY_Caps_Lock    = 0xFFE5
+	XK_KEY_F1           = 0xFFBE
+)
+
+var x11ToHID = func() map[int]IRKey {
+	m := map[int]IRKey{
+		XK_KEY_BackSpace: HID_DELETE, XK_KEY_Tab: HID_TAB, XK_KEY_Return: HID_ENTER,
+		XK_KEY_Escape: HID_ESC, XK_KEY_space: HID_SPACE,
+		XK_KEY_Home: HID_HOME, XK_KEY_Left: HID_LEFT, XK_KEY_Up: HID_UP,
+		XK_KEY_Right: HID_RIGHT, XK_KEY_Down: HID_DOWN,
+		XK_KEY_Page_Up: HID_PAGEUP, XK_KEY_Page_Down: HID_PAGEDOWN,
+		XK_KEY_End: HID_END, XK_KEY_Delete: HID_ForwardDelete,
+		XK_KEY_minus: HID_MINUS, XK_KEY_equal: HID_EQUAL,
+		XK_KEY_bracketleft: HID_LeftBracket, XK_KEY_bracketright: HID_RightBracket,
+		XK_KEY_backslash: HID_BACKSLASH, XK_KEY_semicolon: HID_SEMICOLON,
+		XK_KEY_apostrophe: HID_Quote, XK_KEY_grave: HID_GRAVE,
+		XK_KEY_comma: HID_COMMA, XK_KEY_period: HID_Period, XK_KEY_slash: HID_SLASH,
+		XK_KEY_Caps_Lock: HID_CAPSLOCK,
+		XK_KEY_Shift_L: HID_LeftShift, XK_KEY_Shift_R: HID_RightShift,
+		XK_KEY_Control_L: HID_LeftControl, XK_KEY_Control_R: HID_RightControl,
+		XK_KEY_Alt_L: HID_LeftAlt, XK_KEY_Alt_R: HID_RightAlt,
+		XK_KEY_Super_L: HID_LeftGUI,
+	}
+	for i := 0; i < 10; i++ {
+		m[XK_KEY_0+i] = hidDigit(i)
+	}
+	for i := 0; i < 26; i++ {
+		m[XK_KEY_a+i] = HID_A + IRKey(i)
+	}
+	for i := 0; i < 12; i++ {
+		m[XK_KEY_F1+i] = HID_F1 + IRKey(i)
+	}
+	return m
+}()
+
+// MacKeymap translates macOS virtual keycodes (the XK_* constants
+// declared in ir.go) to and from IR.
+var MacKeymap Keymap = newTableKeymap("mac", macToHID)
+
+// WinKeymap translates Windows VK_* virtual-key codes to and from IR.
+var WinKeymap Keymap = newTableKeymap("windows", winToHID)
+
+// X11Keymap translates Linux/X11 keysyms to and from IR.
+var X11Keymap Keymap = newTableKeymap("x11", x11ToHID)
+
+// hidKeymap is the identity Keymap: native codes are already IR.
+type hidKeymap struct{}
+
+func (hidKeymap) ToIR(native int) (IRKey, error) { return IRKey(native), nil }
+func (hidKeymap) FromIR(k IRKey) (int, error)     { return int(k), nil }
+func (hidKeymap) Name() string                    { return "hid" }
+
+// HIDKeymap is the identity Keymap over USB HID usage IDs, the canonical IR.
+var HIDKeymap Keymap = hidKeymap{}
+
+// Translate converts a slice of native keycodes from the src Keymap's
+// registry to the dst Keymap's registry, by round-tripping each key
+// through the canonical IR. For example a key captured on iOS (src =
+// MacKeymap) can be translated for replay on Windows (dst = WinKeymap).
+func Translate(src, dst Keymap, keys []int) ([]int, error) {
+	out := make([]int, 0, len(keys))
+	for _, k := range keys {
+		ir, err := src.ToIR(k)
+		if err != nil {
+			return nil, err
+		}
+		native, err := dst.FromIR(ir)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, native)
+	}
+	return out, nil
+}
@@ -0,0 +1,51 @@
+package ir
+
+import "testing"
+
+func TestTranslateMacToWin(t *testing.T) {
+	var cases = []struct {
+		in  []int
+		out []int
+	}{
+		{
+			in:  []int{XK_A, XK_Shift},
+			out: []int{VK_A, VK_LSHIFT},
+		},
+		{
+			in:  []int{XK_ENTER},
+			out: []int{VK_RETURN},
+		},
+	}
+
+	for k, cs := range cases {
+		got, err := Translate(MacKeymap, WinKeymap, cs.in)
+		if err != nil {
+			t.Fatalf("case %v: err %v", k, err)
+		}
+		if len(got) != len(cs.out) {
+			t.Fatalf("case %v: e %#v, r %#v", k, cs.out, got)
+		}
+		for i := range got {
+			if got[i] != cs.out[i] {
+				t.Errorf("case %v: e %#v, r %#v", k, cs.out, got)
+			}
+		}
+	}
+}
+
+func TestTranslateRoundTrip(t *testing.T) {
+	keys := []int{XK_H, XK_E, XK_L, XK_L, XK_O}
+	win, err := Translate(MacKeymap, WinKeymap, keys)
+	if err != nil {
+		t.Fatalf("mac->win: %v", err)
+	}
+	back, err := Translate(WinKeymap, MacKeymap, win)
+	if err != nil {
+		t.Fatalf("win->mac: %v", err)
+	}
+	for i := range keys {
+		if keys[i] != back[i] {
+			t.Errorf("round trip mismatch at %v: e %v, r %v", i, keys[i], back[i])
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package ir
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Layout maps the runes a physical keyboard can produce to the key (and,
+// implicitly, the modifier combination) that produces them: Base with no
+// modifier, Shift with Shift held, AltGr with AltGr (right Alt) held, and
+// ShiftAltGr with both. This generalizes the old shift-uppercase special
+// case in ToKeys to cover shifted symbols and non-US layouts, e.g. typing
+// ä or € on a German layout.
+type Layout struct {
+	Base, Shift, AltGr, ShiftAltGr map[rune]int
+}
+
+// shiftedSymbolsUS maps the symbols a US keyboard produces with Shift
+// held to the unshifted key that, combined with Shift, produces them.
+var shiftedSymbolsUS = map[rune]int{
+	'!': XK_1, '@': XK_2, '#': XK_3, '$': XK_4, '%': XK_5,
+	'^': XK_6, '&': XK_7, '*': XK_8, '(': XK_9, ')': XK_0,
+	'_': XK_MINUS, '+': XK_EQUAL,
+	'{': XK_LeftBracket, '}': XK_RightBracket, '|': XK_BACKSLASH,
+	':': XK_SEMICOLON, '"': XK_Quote, '~': XK_GRAVE,
+	'<': XK_COMMA, '>': XK_Period, '?': XK_SLASH,
+}
+
+// keypadKeys are stringMap entries that duplicate a main-row rune (e.g.
+// both XK_Period and XK_KeypadDecimal produce "."); baseFromStringMap
+// prefers the main-row key so the result doesn't depend on map iteration
+// order.
+var keypadKeys = map[int]bool{
+	XK_KeypadDecimal: true, XK_KeypadMultiply: true, XK_KeypadPlus: true,
+	XK_KeypadClear: true, XK_KeypadDivide: true, XK_KeypadEnter: true,
+	XK_KeypadMinus: true, XK_KeypadEquals: true,
+	XK_Keypad0: true, XK_Keypad1: true, XK_Keypad2: true, XK_Keypad3: true,
+	XK_Keypad4: true, XK_Keypad5: true, XK_Keypad6: true, XK_Keypad7: true,
+	XK_Keypad8: true, XK_Keypad9: true,
+}
+
+// baseFromStringMap builds a Layout.Base from stringMap's single-rune
+// entries, preferring the main-row key over its keypad duplicate so the
+// result doesn't depend on map iteration order.
+func baseFromStringMap() map[rune]int {
+	m := make(map[rune]int, len(stringMap))
+	add := func(wantKeypad bool) {
+		for key, s := range stringMap {
+			if keypadKeys[key] != wantKeypad {
+				continue
+			}
+			r := []rune(s)
+			if len(r) != 1 {
+				continue
+			}
+			if _, exists := m[r[0]]; exists {
+				continue
+			}
+			m[r[0]] = key
+		}
+	}
+	add(false) // main-row keys first
+	add(true)  // then fill any rune only the keypad produces
+	return m
+}
+
+func shiftFromBase(base map[rune]int) map[rune]int {
+	m := make(map[rune]int, 26)
+	for r, key := range base {
+		if unicode.IsLetter(r) {
+			m[unicode.ToUpper(r)] = key
+		}
+	}
+	for r, key := range shiftedSymbolsUS {
+		m[r] = key
+	}
+	return m
+}
+
+// LayoutUS is the standard US QWERTY layout.
+var LayoutUS = Layout{
+	Base:  baseFromStringMap(),
+	Shift: shiftFromBase(baseFromStringMap()),
+}
+
+// LayoutUKQwerty is a UK QWERTY layout. It differs from LayoutUS only in
+// three shifted symbols: " is Shift+2, @ is Shift+' and £ is Shift+3;
+// every other symbol, # included, keeps its US position.
+var LayoutUKQwerty = Layout{
+	Base: LayoutUS.Base,
+	Shift: mergeRuneMaps(LayoutUS.Shift, map[rune]int{
+		'"': XK_2,
+		'@': XK_Quote,
+		'£': XK_3,
+	}),
+}
+
+// LayoutDE is a German QWERTZ layout: Y and Z are swapped versus QWERTY,
+// ä/ö/ü/ß sit where US punctuation keys are, and common symbols move
+// behind AltGr.
+var LayoutDE = Layout{
+	Base: mergeRuneMaps(LayoutUS.Base, map[rune]int{
+		'z': XK_Y, 'y': XK_Z,
+		'ä': XK_Quote, 'ö': XK_SEMICOLON, 'ü': XK_LeftBracket,
+		'ß': XK_MINUS,
+	}),
+	Shift: mergeRuneMaps(LayoutUS.Shift, map[rune]int{
+		'Z': XK_Y, 'Y': XK_Z,
+		'Ä': XK_Quote, 'Ö': XK_SEMICOLON, 'Ü': XK_LeftBracket,
+	}),
+	AltGr: map[rune]int{
+		'@': XK_Q, '€': XK_E, '{': XK_7, '[': XK_8, ']': XK_9, '}': XK_0,
+	},
+}
+
+// LayoutFR_AZERTY is a French AZERTY layout: A/Q and Z/W are swapped
+// versus QWERTY, M sits where US semicolon is, and digits live behind
+// Shift on the top row.
+var LayoutFR_AZERTY = Layout{
+	Base: mergeRuneMaps(LayoutUS.Base, map[rune]int{
+		'a': XK_Q, 'q': XK_A, 'z': XK_W, 'w': XK_Z, 'm': XK_SEMICOLON,
+	}),
+	Shift: mergeRuneMaps(LayoutUS.Shift, map[rune]int{
+		'A': XK_Q, 'Q': XK_A, 'Z': XK_W, 'W': XK_Z, 'M': XK_SEMICOLON,
+		'1': XK_1, '2': XK_2, '3': XK_3, '4': XK_4, '5': XK_5,
+		'6': XK_6, '7': XK_7, '8': XK_8, '9': XK_9, '0': XK_0,
+	}),
+	AltGr: map[rune]int{
+		'€': XK_E, '#': XK_3, '@': XK_0,
+	},
+}
+
+// dvorakBase maps the Dvorak Simplified Keyboard layout to the physical
+// (QWERTY-labeled) key that produces each letter.
+var dvorakBase = mergeRuneMaps(LayoutUS.Base, map[rune]int{
+	'\'': XK_Q, ',': XK_W, '.': XK_E, 'p': XK_R, 'y': XK_T,
+	'f': XK_Y, 'g': XK_U, 'c': XK_I, 'r': XK_O, 'l': XK_P,
+	'a': XK_A, 'o': XK_S, 'e': XK_D, 'u': XK_F, 'i': XK_G,
+	'd': XK_H, 'h': XK_J, 't': XK_K, 'n': XK_L, 's': XK_SEMICOLON,
+	';': XK_Z, 'q': XK_X, 'j': XK_C, 'k': XK_V, 'x': XK_B,
+	'b': XK_N, 'm': XK_M, 'w': XK_COMMA, 'v': XK_Period, 'z': XK_SLASH,
+	'-': XK_Quote,
+})
+
+// LayoutDvorak is the Dvorak Simplified Keyboard layout.
+var LayoutDvorak = Layout{
+	Base:  dvorakBase,
+	Shift: shiftFromBase(dvorakBase),
+}
+
+func mergeRuneMaps(base map[rune]int, overrides map[rune]int) map[rune]int {
+	m := make(map[rune]int, len(base)+len(overrides))
+	for r, k := range base {
+		m[r] = k
+	}
+	for r, k := range overrides {
+		m[r] = k
+	}
+	return m
+}
+
+var layouts = map[string]Layout{
+	"us":    LayoutUS,
+	"uk":    LayoutUKQwerty,
+	"de":    LayoutDE,
+	"fr":    LayoutFR_AZERTY,
+	"dvorak": LayoutDvorak,
+}
+
+// activeLayout is the Layout runeToKey consults; Parse and ToKeys have no
+// per-call layout argument, so callers switch layouts through
+// SetActiveLayout (directly, or with a Layout obtained from
+// DetectLayout()/RegisterLayout's registry).
+var activeLayout = LayoutUS
+
+// SetActiveLayout changes the Layout that Parse and ToKeys (and
+// LoadCompose, for single-character names) consult to resolve a rune to
+// a key; ToString does not use it. For example SetActiveLayout(LayoutDE) so typing
+// "ä" produces the physical key a German keyboard uses for it instead of
+// falling back to a Compose sequence. It affects every call made after
+// it returns; there is no per-call layout argument.
+func SetActiveLayout(l Layout) {
+	activeLayout = l
+}
+
+// RegisterLayout adds (or replaces) a named layout in the registry that
+// GetLayout looks up by name. DetectLayout does not consult the registry.
+func RegisterLayout(name string, l Layout) {
+	layouts[strings.ToLower(name)] = l
+}
+
+// GetLayout looks up a registered layout by the name it was registered
+// under (lowercased); built-ins are "us", "uk", "de", "fr", "dvorak".
+func GetLayout(name string) (Layout, bool) {
+	l, ok := layouts[strings.ToLower(name)]
+	return l, ok
+}
+
+// DetectLayout makes a best-effort guess at the active keyboard layout
+// from the process locale (LC_ALL / LANG), falling back to LayoutUS. It
+// is a heuristic, not a query of the OS's actual input source: a user
+// with an en_US locale but a German keyboard attached won't be detected.
+func DetectLayout() Layout {
+	locale := os.Getenv("LC_ALL")
+	if locale == "" {
+		locale = os.Getenv("LANG")
+	}
+	locale = strings.ToLower(locale)
+	switch {
+	case strings.HasPrefix(locale, "de"):
+		return LayoutDE
+	case strings.HasPrefix(locale, "fr"):
+		return LayoutFR_AZERTY
+	case strings.HasPrefix(locale, "en_gb"):
+		return LayoutUKQwerty
+	default:
+		return LayoutUS
+	}
+}
+
+// lookup finds the key and modifiers needed to type r under l, checking
+// Base, Shift, AltGr and ShiftAltGr in that order.
+func (l Layout) lookup(r rune) (key int, mods Modifier, ok bool) {
+	if k, ok := l.Base[r]; ok {
+		return k, 0, true
+	}
+	if k, ok := l.Shift[r]; ok {
+		return k, ModShift, true
+	}
+	if k, ok := l.AltGr[r]; ok {
+		return k, ModAlt, true
+	}
+	if k, ok := l.ShiftAltGr[r]; ok {
+		return k, ModShift | ModAlt, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,25 @@
+package ir_test
+
+import (
+	"testing"
+
+	"github.com/themihai/keybd_event/ir"
+)
+
+// TestSetActiveLayoutFromOutsidePackage confirms a caller outside package
+// ir can actually make ToKeys use a non-US layout: previously
+// activeLayout was unexported with no setter, so LayoutDE/LayoutFR_AZERTY/
+// LayoutUKQwerty/LayoutDvorak were unreachable dead code to anyone but
+// package ir's own tests.
+func TestSetActiveLayoutFromOutsidePackage(t *testing.T) {
+	ir.SetActiveLayout(ir.LayoutDE)
+	defer ir.SetActiveLayout(ir.LayoutUS)
+
+	keys, err := ir.ToKeys("ä")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	if len(keys) != 1 || keys[0] != ir.XK_Quote {
+		t.Errorf("expected 'ä' to resolve via LayoutDE to XK_Quote, got %#v", keys)
+	}
+}
@@ -0,0 +1,57 @@
+package ir
+
+import "testing"
+
+func TestLayoutShiftedSymbols(t *testing.T) {
+	var cases = []struct {
+		in  string
+		out []int
+	}{
+		{in: "!", out: []int{XK_Shift, XK_1}},
+		{in: "@", out: []int{XK_Shift, XK_2}},
+		{in: "?", out: []int{XK_Shift, XK_SLASH}},
+	}
+	for k, cs := range cases {
+		got, err := ToKeys(cs.in)
+		if err != nil {
+			t.Fatalf("case %v: err %v", k, err)
+		}
+		if len(got) != len(cs.out) || got[0] != cs.out[0] || got[1] != cs.out[1] {
+			t.Errorf("case %v: e %#v, r %#v", k, cs.out, got)
+		}
+	}
+}
+
+func TestLayoutDE(t *testing.T) {
+	prev := activeLayout
+	SetActiveLayout(LayoutDE)
+	defer func() { activeLayout = prev }()
+
+	keys, err := ToKeys("y")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	if len(keys) != 1 || keys[0] != XK_Z {
+		t.Errorf("expected the 'y' rune to resolve to the physical Z key on LayoutDE, got %#v", keys)
+	}
+
+	akeys, err := ToKeys("ä")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	if len(akeys) != 1 || akeys[0] != XK_Quote {
+		t.Errorf("expected 'ä' to resolve to XK_Quote on LayoutDE, got %#v", akeys)
+	}
+}
+
+func TestRegisterLayout(t *testing.T) {
+	custom := Layout{Base: map[rune]int{'!': XK_1}}
+	RegisterLayout("custom", custom)
+	got, ok := GetLayout("custom")
+	if !ok {
+		t.Fatal("expected custom layout to be registered")
+	}
+	if got.Base['!'] != XK_1 {
+		t.Errorf("unexpected layout contents: %#v", got)
+	}
+}
@@ -0,0 +1,314 @@
+package ir
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Modifier is a bitmask of held modifier keys, used by Event and Parse.
+type Modifier int
+
+const (
+	ModShift Modifier = 1 << iota
+	ModCtrl
+	ModAlt
+	ModCmd
+)
+
+// Action describes what a parsed Event does to its Key: a full tap (press
+// then release), or one half of an explicit press/release pair such as
+// the one produced by "{ctrl down}...{ctrl up}".
+type Action int
+
+const (
+	ActionTap Action = iota
+	ActionPress
+	ActionRelease
+)
+
+// Event is one step of a parsed key-event sequence: a chord or character
+// to tap, an explicit modifier press/release, a repeat of a named key, or
+// a pure delay (IsDelay true, everything else zero). See Parse.
+type Event struct {
+	Mods    Modifier
+	Key     int
+	Rune    rune
+	Action  Action
+	Repeat  int
+	Delay   time.Duration
+	IsDelay bool
+}
+
+// ParseError reports a malformed key-event sequence, pointing at the byte
+// offset in the original string where parsing failed.
+type ParseError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("ir: parse error at offset %d: %s", e.Offset, e.Msg)
+}
+
+// namedKeys resolves the special names accepted inside <...> and {...}
+// groups (and as the final segment of a chord) to a key code.
+var namedKeys = map[string]int{
+	"enter": XK_ENTER, "return": XK_ENTER,
+	"space": XK_SPACE,
+	"tab":   XK_TAB,
+	"esc":   XK_ESC, "escape": XK_ESC,
+	"home": XK_HOME, "end": XK_END,
+	"pgup": XK_PAGEUP, "pgdn": XK_PAGEDOWN,
+	"up": XK_UP, "down": XK_DOWN, "left": XK_LEFT, "right": XK_RIGHT,
+	"f1": XK_F1, "f2": XK_F2, "f3": XK_F3, "f4": XK_F4, "f5": XK_F5,
+	"f6": XK_F6, "f7": XK_F7, "f8": XK_F8, "f9": XK_F9, "f10": XK_F10,
+	"f11": XK_F11, "f12": XK_F12, "f13": XK_F13, "f14": XK_F14, "f15": XK_F15,
+	"f16": XK_F16, "f17": XK_F17, "f18": XK_F18, "f19": XK_F19, "f20": XK_F20,
+}
+
+// modifierKeys resolves the modifier names accepted in a chord ("ctrl+..")
+// or an explicit down/up group ("{ctrl down}") to a Modifier bit and the
+// key code to emit for an explicit press/release of that modifier.
+var modifierKeys = map[string]struct {
+	mod Modifier
+	key int
+}{
+	"ctrl": {ModCtrl, XK_Control}, "control": {ModCtrl, XK_Control},
+	"shift":   {ModShift, XK_Shift},
+	"alt":     {ModAlt, XK_ALT},
+	"option":  {ModAlt, XK_ALT},
+	"cmd":     {ModCmd, XK_Command},
+	"command": {ModCmd, XK_Command},
+	"super":   {ModCmd, XK_Command},
+	"win":     {ModCmd, XK_Command},
+}
+
+// Parse reads a key-event sequence description and returns the Events it
+// describes. It accepts:
+//
+//   - a chord, e.g. "ctrl+shift+k" or "alt+tab"
+//   - a named special wrapped in angle brackets, e.g. "<F5>"
+//   - an inline delay, e.g. "<wait:500ms>"
+//   - plain text mixed with braced specials, e.g. "{enter}hello{space}world"
+//   - an explicit press/release pair, e.g. "{ctrl down}k{ctrl up}"
+//   - a repeat count, e.g. "{tab 3}"
+//
+// Errors identify the byte offset in s of the offending token.
+func Parse(s string) ([]Event, error) {
+	if !strings.ContainsAny(s, "{<") && strings.Contains(s, "+") {
+		ev, err := parseChord(s, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []Event{ev}, nil
+	}
+
+	var events []Event
+	i := 0
+	for i < len(s) {
+		switch s[i] {
+		case '<':
+			end := strings.IndexByte(s[i:], '>')
+			if end < 0 {
+				return nil, &ParseError{i, "unterminated '<'"}
+			}
+			content := s[i+1 : i+end]
+			ev, err := parseAngle(content, i+1)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, ev)
+			i += end + 1
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return nil, &ParseError{i, "unterminated '{'"}
+			}
+			content := s[i+1 : i+end]
+			ev, err := parseBrace(content, i+1)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, ev)
+			i += end + 1
+		default:
+			v, size := utf8.DecodeRuneInString(s[i:])
+			evs, err := charEvent(v, i)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, evs...)
+			i += size
+		}
+	}
+	return events, nil
+}
+
+// charEvent resolves a plain-text rune to the Event(s) needed to type it:
+// a single Tap (with any modifiers it needs) for anything runeToKey
+// resolves through the active layout or stringMap, or a Compose-key
+// sequence (see ComposeTable) for everything else.
+func charEvent(v rune, offset int) ([]Event, error) {
+	key, mods, err := runeToKey(v)
+	if err == nil {
+		return []Event{{Mods: mods, Key: key, Rune: v, Action: ActionTap}}, nil
+	}
+	seq, ok := Compose[v]
+	if !ok {
+		return nil, &ParseError{offset, fmt.Sprintf("rune %U (%q) not found and has no compose sequence", v, v)}
+	}
+	events := make([]Event, len(seq))
+	for i, k := range seq {
+		events[i] = Event{Key: k, Action: ActionTap}
+	}
+	events[len(events)-1].Rune = v
+	return events, nil
+}
+
+// runeToKey resolves a plain-text rune to a key code and the modifiers
+// needed to type it, consulting activeLayout (Base, then Shift, then
+// AltGr, then ShiftAltGr) first so shifted symbols and layout-specific
+// characters (ä, €, ...) resolve to the right chord.
+func runeToKey(v rune) (key int, mods Modifier, err error) {
+	if key, mods, ok := activeLayout.lookup(v); ok {
+		return key, mods, nil
+	}
+	// Fall back to a straight stringMap scan for anything the active
+	// layout doesn't list explicitly, e.g. an uppercase letter a layout
+	// only defines in lowercase.
+	upper := unicode.IsUpper(v)
+	lower := v
+	if upper {
+		lower = unicode.ToLower(v)
+	}
+	for k, ks := range stringMap {
+		if strings.EqualFold(ks, string(lower)) {
+			if upper {
+				mods |= ModShift
+			}
+			return k, mods, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("key for rune %v (hex: %+q) not found", v, v)
+}
+
+func parseAngle(content string, offset int) (Event, error) {
+	if strings.HasPrefix(content, "wait:") {
+		d, err := time.ParseDuration(strings.TrimPrefix(content, "wait:"))
+		if err != nil {
+			return Event{}, &ParseError{offset, fmt.Sprintf("bad wait duration %q: %v", content, err)}
+		}
+		return Event{Delay: d, IsDelay: true}, nil
+	}
+	key, ok := namedKeys[strings.ToLower(content)]
+	if !ok {
+		return Event{}, &ParseError{offset, fmt.Sprintf("unknown special %q", content)}
+	}
+	return Event{Key: key, Action: ActionTap}, nil
+}
+
+func parseBrace(content string, offset int) (Event, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return Event{}, &ParseError{offset, "empty '{}'"}
+	}
+	name := strings.ToLower(fields[0])
+
+	if len(fields) == 2 {
+		switch fields[1] {
+		case "down", "up":
+			mk, ok := modifierKeys[name]
+			if !ok {
+				return Event{}, &ParseError{offset, fmt.Sprintf("%q cannot be held down/up", fields[0])}
+			}
+			action := ActionPress
+			if fields[1] == "up" {
+				action = ActionRelease
+			}
+			return Event{Key: mk.key, Action: action}, nil
+		default:
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return Event{}, &ParseError{offset, fmt.Sprintf("bad repeat count %q", fields[1])}
+			}
+			key, ok := namedKeys[name]
+			if !ok {
+				return Event{}, &ParseError{offset, fmt.Sprintf("unknown special %q", fields[0])}
+			}
+			return Event{Key: key, Action: ActionTap, Repeat: n}, nil
+		}
+	}
+
+	if len(fields) != 1 {
+		return Event{}, &ParseError{offset, fmt.Sprintf("malformed '{%s}'", content)}
+	}
+	key, ok := namedKeys[name]
+	if !ok {
+		return Event{}, &ParseError{offset, fmt.Sprintf("unknown special %q", fields[0])}
+	}
+	return Event{Key: key, Action: ActionTap}, nil
+}
+
+// parseChord parses a modifier chord like "ctrl+shift+k" into a single Tap
+// Event. The final '+'-separated segment is the key; every segment before
+// it must be a known modifier name.
+func parseChord(s string, offset int) (Event, error) {
+	parts := strings.Split(s, "+")
+	var mods Modifier
+	pos := offset
+	for _, p := range parts[:len(parts)-1] {
+		mk, ok := modifierKeys[strings.ToLower(strings.TrimSpace(p))]
+		if !ok {
+			return Event{}, &ParseError{pos, fmt.Sprintf("unknown modifier %q", p)}
+		}
+		mods |= mk.mod
+		pos += len(p) + 1
+	}
+	last := strings.ToLower(strings.TrimSpace(parts[len(parts)-1]))
+	if key, ok := namedKeys[last]; ok {
+		return Event{Mods: mods, Key: key, Action: ActionTap}, nil
+	}
+	if r := []rune(last); len(r) == 1 {
+		key, _, err := runeToKey(r[0])
+		if err != nil {
+			return Event{}, &ParseError{pos, err.Error()}
+		}
+		return Event{Mods: mods, Key: key, Action: ActionTap}, nil
+	}
+	return Event{}, &ParseError{pos, fmt.Sprintf("unknown chord key %q", parts[len(parts)-1])}
+}
+
+// flatten drops timing and press/release distinctions, reducing Events to
+// the plain key-code sequence ToKeys has always returned.
+func flatten(events []Event) []int {
+	var keys []int
+	for _, e := range events {
+		if e.IsDelay {
+			continue
+		}
+		n := e.Repeat
+		if n < 1 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			if e.Mods&ModShift != 0 {
+				keys = append(keys, XK_Shift)
+			}
+			if e.Mods&ModCtrl != 0 {
+				keys = append(keys, XK_Control)
+			}
+			if e.Mods&ModAlt != 0 {
+				keys = append(keys, XK_ALT)
+			}
+			if e.Mods&ModCmd != 0 {
+				keys = append(keys, XK_Command)
+			}
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,79 @@
+package ir
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseChord(t *testing.T) {
+	var cases = []struct {
+		in  string
+		out []Event
+	}{
+		{
+			in:  "ctrl+shift+k",
+			out: []Event{{Mods: ModCtrl | ModShift, Key: XK_K, Action: ActionTap}},
+		},
+		{
+			in:  "alt+tab",
+			out: []Event{{Mods: ModAlt, Key: XK_TAB, Action: ActionTap}},
+		},
+	}
+
+	for k, cs := range cases {
+		got, err := Parse(cs.in)
+		if err != nil {
+			t.Fatalf("case %v: err %v", k, err)
+		}
+		if !reflect.DeepEqual(got, cs.out) {
+			t.Errorf("case %v: e %#v, r %#v", k, cs.out, got)
+		}
+	}
+}
+
+func TestParseMixedSequence(t *testing.T) {
+	got, err := Parse("{enter}hi{space}{tab 2}")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	want := []Event{
+		{Key: XK_ENTER, Action: ActionTap},
+		{Key: XK_H, Action: ActionTap, Rune: 'h'},
+		{Key: XK_I, Action: ActionTap, Rune: 'i'},
+		{Key: XK_SPACE, Action: ActionTap},
+		{Key: XK_TAB, Action: ActionTap, Repeat: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("e %#v, r %#v", want, got)
+	}
+}
+
+func TestParseDownUpAndWait(t *testing.T) {
+	got, err := Parse("{ctrl down}<wait:10ms>{ctrl up}")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	want := []Event{
+		{Key: XK_Control, Action: ActionPress},
+		{Delay: 10 * time.Millisecond, IsDelay: true},
+		{Key: XK_Control, Action: ActionRelease},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("e %#v, r %#v", want, got)
+	}
+}
+
+func TestParseErrorOffset(t *testing.T) {
+	_, err := Parse("hi<bogus>")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %T", err)
+	}
+	if pe.Offset != 3 {
+		t.Errorf("expected offset 3, got %v", pe.Offset)
+	}
+}
@@ -0,0 +1,45 @@
+package transport
+
+// A WebSocket sender built on gorilla/websocket looks like this. It's
+// kept as a doc comment rather than a compiled file because this module
+// has no dependency manifest to pull gorilla/websocket in from; drop it
+// into a file of its own once the module does.
+//
+// A WebSocket hands out a new reader and writer per message, while an
+// ir.Stream keeps the first reader it is given and writes the wire
+// header only once, so each message gets a Stream of its own and carries
+// the header plus one Event.
+//
+//	func sendOverWebSocket(conn *websocket.Conn, events []ir.Event) error {
+//		for _, e := range events {
+//			var stream ir.Stream
+//			w, err := conn.NextWriter(websocket.BinaryMessage)
+//			if err != nil {
+//				return err
+//			}
+//			if err := stream.WriteEvent(w, e); err != nil {
+//				return err
+//			}
+//			if err := w.Close(); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	}
+//
+//	func receiveFromWebSocket(conn *websocket.Conn, replay func(ir.Event) error) error {
+//		for {
+//			var stream ir.Stream
+//			_, r, err := conn.NextReader()
+//			if err != nil {
+//				return err
+//			}
+//			e, err := stream.ReadEvent(r)
+//			if err != nil {
+//				return err
+//			}
+//			if err := replay(e); err != nil {
+//				return err
+//			}
+//		}
+//	}
@@ -0,0 +1,44 @@
+// Package transport has reference examples of shipping ir.Event sequences
+// between platforms over the wire codec in package ir (Encode/Decode,
+// Stream). Handler is a minimal working example; see doc.go for a
+// WebSocket variant built on gorilla/websocket.
+package transport
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/themihai/keybd_event/ir"
+)
+
+// Handler receives a POST body in ir's binary wire format, decodes it,
+// and calls Replay with the resulting events. It's a reference example
+// of the receiving end described in package ir's docs: a capture app on
+// one platform POSTs here, and Replay (left to the caller) drives the
+// local keybd_event backend.
+type Handler struct {
+	// Replay is called with the decoded events for each request.
+	Replay func(events []ir.Event) error
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := ir.Decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Replay(events); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}